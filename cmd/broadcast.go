@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	authclient "github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+)
+
+// BroadcastMode selects how a Broadcaster waits (or doesn't) for a
+// submitted tx, mirroring the CLIContext broadcast modes.
+type BroadcastMode string
+
+const (
+	BroadcastModeSync  BroadcastMode = "sync"
+	BroadcastModeAsync BroadcastMode = "async"
+	BroadcastModeBlock BroadcastMode = "block"
+)
+
+// TxResult is the outcome of a single broadcast submitted through a
+// Broadcaster.
+type TxResult struct {
+	Response sdk.TxResponse
+	Err      error
+}
+
+// Broadcaster signs and submits batches of messages on behalf of the
+// deploying account. Implementations own account sequence management so
+// that callers can submit from multiple goroutines (parallel AddOrder/
+// AddLease reactions) without racing each other's signature.
+type Broadcaster interface {
+	// Send queues msgs for signing and broadcast, returning a future for
+	// the result. Safe to call concurrently.
+	Send(msgs []sdk.Msg) <-chan TxResult
+	// Close stops any background goroutines the Broadcaster owns.
+	Close()
+}
+
+// broadcastReq is one pending Send call waiting on the serializer
+// goroutine.
+type broadcastReq struct {
+	msgs []sdk.Msg
+	out  chan<- TxResult
+}
+
+// buildReq is one pending Build call waiting on the serializer goroutine.
+type buildReq struct {
+	msgs []sdk.Msg
+	out  chan<- buildResult
+}
+
+type buildResult struct {
+	bytes []byte
+	err   error
+}
+
+// Serial is a Broadcaster that funnels every Send through a single
+// goroutine so signing never races over the account sequence number. It
+// caches the account number and next sequence locally instead of
+// refetching them on every send, and resyncs from the chain once if a
+// broadcast comes back with a sequence mismatch.
+type Serial struct {
+	cfg  *Config
+	mode BroadcastMode
+
+	reqCh   chan broadcastReq
+	buildCh chan buildReq
+	done    chan struct{}
+
+	mu       sync.Mutex
+	accNum   uint64
+	sequence uint64
+	synced   bool
+}
+
+// NewSerialBroadcaster starts the signing goroutine for cfg and returns a
+// Broadcaster that serializes every Send call through it.
+func NewSerialBroadcaster(cfg *Config, mode BroadcastMode) *Serial {
+	b := &Serial{
+		cfg:     cfg,
+		mode:    mode,
+		reqCh:   make(chan broadcastReq),
+		buildCh: make(chan buildReq),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *Serial) run() {
+	for {
+		select {
+		case req := <-b.reqCh:
+			res, err := b.signAndBroadcast(req.msgs)
+			req.out <- TxResult{Response: res, Err: err}
+		case req := <-b.buildCh:
+			bz, err := b.build(req.msgs)
+			req.out <- buildResult{bytes: bz, err: err}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Send implements Broadcaster.
+func (b *Serial) Send(msgs []sdk.Msg) <-chan TxResult {
+	out := make(chan TxResult, 1)
+	b.reqCh <- broadcastReq{msgs: msgs, out: out}
+	return out
+}
+
+// Build signs and marshals msgs against the next reserved sequence number
+// without broadcasting them, going through the same serializer goroutine
+// as Send so the sequence it reserves can't race a concurrent Send.
+func (b *Serial) Build(msgs []sdk.Msg) ([]byte, error) {
+	out := make(chan buildResult, 1)
+	b.buildCh <- buildReq{msgs: msgs, out: out}
+	res := <-out
+	return res.bytes, res.err
+}
+
+// Close implements Broadcaster.
+func (b *Serial) Close() {
+	close(b.done)
+}
+
+// signAndBroadcast signs msgs against the cached sequence and broadcasts
+// them, retrying once against a freshly-fetched sequence if the node
+// rejects the tx as unauthorized (our local sequence fell behind, e.g.
+// after a tx sent from another process).
+func (b *Serial) signAndBroadcast(msgs []sdk.Msg) (sdk.TxResponse, error) {
+	res, err := b.trySend(msgs)
+	if err != nil || !isSequenceMismatch(res) {
+		return res, err
+	}
+
+	b.mu.Lock()
+	b.synced = false
+	b.mu.Unlock()
+
+	return b.trySend(msgs)
+}
+
+func (b *Serial) trySend(msgs []sdk.Msg) (sdk.TxResponse, error) {
+	accNum, sequence, err := b.nextSequence()
+	if err != nil {
+		return sdk.TxResponse{}, err
+	}
+
+	out, err := b.buildAndSign(accNum, sequence, msgs)
+	if err != nil {
+		return sdk.TxResponse{}, err
+	}
+
+	res, err := b.broadcast(out)
+	if err != nil {
+		return res, err
+	}
+	if !isSequenceMismatch(res) {
+		b.mu.Lock()
+		b.sequence = sequence + 1
+		b.mu.Unlock()
+	}
+	return res, nil
+}
+
+// build signs msgs against the next reserved sequence and advances it,
+// exactly as trySend does for a broadcast, so a later Send or Build can't
+// reuse the sequence this call just claimed.
+func (b *Serial) build(msgs []sdk.Msg) ([]byte, error) {
+	accNum, sequence, err := b.nextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := b.buildAndSign(accNum, sequence, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.sequence = sequence + 1
+	b.mu.Unlock()
+
+	return out, nil
+}
+
+// nextSequence returns the cached account number and next sequence,
+// fetching them from the chain the first time or after a resync.
+func (b *Serial) nextSequence() (accNum, sequence uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.synced {
+		ctx := b.cfg.CLICtx(b.cfg.NewTMClient())
+		acc, err := auth.NewAccountRetriever(ctx).GetAccount(b.cfg.GetAccAddress())
+		if err != nil {
+			return 0, 0, err
+		}
+		b.accNum = acc.GetAccountNumber()
+		b.sequence = acc.GetSequence()
+		b.synced = true
+	}
+
+	return b.accNum, b.sequence, nil
+}
+
+func (b *Serial) buildAndSign(accNum, sequence uint64, msgs []sdk.Msg) ([]byte, error) {
+	cfg := b.cfg
+	ctx := cfg.CLICtx(cfg.NewTMClient())
+
+	txBldr := auth.NewTxBuilder(
+		auth.DefaultTxEncoder(cfg.Amino),
+		accNum,
+		sequence,
+		200000,
+		cfg.gasAdj,
+		true,
+		cfg.ChainID,
+		"",
+		sdk.NewCoins(),
+		cfg.gasPrices,
+	).WithKeybase(cfg.keybase)
+
+	txBldr, err := authclient.EnrichWithGas(txBldr, ctx, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return txBldr.BuildAndSign(cfg.keyName(), signPassphrase(), msgs)
+}
+
+func (b *Serial) broadcast(txBytes []byte) (sdk.TxResponse, error) {
+	ctx := b.cfg.CLICtx(b.cfg.NewTMClient())
+	ctx.BroadcastMode = string(b.mode)
+	return ctx.BroadcastTx(txBytes)
+}
+
+// isSequenceMismatch reports whether a broadcast failed because our
+// locally cached sequence number no longer matches the chain's, by
+// checking the actual ABCI result code rather than sniffing RawLog text.
+func isSequenceMismatch(res sdk.TxResponse) bool {
+	return res.Code == uint32(sdk.CodeUnauthorized)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestIsSequenceMismatch(t *testing.T) {
+	cases := []struct {
+		name string
+		res  sdk.TxResponse
+		want bool
+	}{
+		{"ok", sdk.TxResponse{Code: 0}, false},
+		{"unauthorized", sdk.TxResponse{Code: uint32(sdk.CodeUnauthorized)}, true},
+		{"other failure", sdk.TxResponse{Code: uint32(sdk.CodeInsufficientFunds)}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isSequenceMismatch(c.res); got != c.want {
+				t.Errorf("isSequenceMismatch(%+v) = %v, want %v", c.res, got, c.want)
+			}
+		})
+	}
+}
@@ -12,39 +12,60 @@ import (
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/crypto/keys"
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	"github.com/cosmos/cosmos-sdk/x/auth"
-	authclient "github.com/cosmos/cosmos-sdk/x/auth/client/utils"
-	"github.com/cosmos/go-bip39"
+	"github.com/jackzampolin/deploy/tmclient"
 	"github.com/ovrclk/akash/app"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
 	"gopkg.in/yaml.v2"
 )
 
 var (
 	akashPrefix = "akash"
 	defaultKey  = "default"
-	defaultPass = "12345678"
 )
 
 // Config represents the application configuration
 type Config struct {
 	ChainID string `yaml:"chain-id" json:"chain-id"`
-	RPCAddr string `yaml:"rpc-addr" json:"rpc-addr"`
-	Keyfile string `yaml:"keyfile" json:"keyfile"`
-	Keypass string `yaml:"keypass" json:"keypass"`
+	// RPCAddr is kept for backward compatibility with configs written
+	// before multi-node support; it is folded into RPCAddrs if that list
+	// is empty.
+	RPCAddr        string   `yaml:"rpc-addr" json:"rpc-addr"`
+	RPCAddrs       []string `yaml:"rpc-addrs" json:"rpc-addrs"`
+	KeyringBackend string   `yaml:"keyring-backend" json:"keyring-backend"`
+	KeyName        string   `yaml:"key-name" json:"key-name"`
 
 	gasAdj    float64
 	gasPrices sdk.DecCoins
+	useLedger bool
 
-	keybase keys.Keybase
-	address sdk.AccAddress
-	Amino   *codec.Codec
+	keybase     keys.Keybase
+	address     sdk.AccAddress
+	Amino       *codec.Codec
+	broadcaster Broadcaster
+	pool        *tmclient.Pool
+}
+
+// rpcAddrs returns the configured node list, folding the legacy single
+// RPCAddr in if RPCAddrs wasn't set.
+func (c *Config) rpcAddrs() []string {
+	if len(c.RPCAddrs) > 0 {
+		return c.RPCAddrs
+	}
+	return []string{c.RPCAddr}
+}
+
+// Broadcaster returns the Config's Broadcaster, lazily creating the
+// default Serial implementation on first use.
+func (c *Config) Broadcaster() Broadcaster {
+	if c.broadcaster == nil {
+		c.broadcaster = NewSerialBroadcaster(c, BroadcastModeSync)
+	}
+	return c.broadcaster
 }
 
 // CLICtx returns the CLICtx object with some defaults set
-func (c *Config) CLICtx(client *rpchttp.HTTP) cctx.CLIContext {
+func (c *Config) CLICtx(client tmclient.Client) cctx.CLIContext {
 	return cctx.CLIContext{
 		FromAddress:   c.address,
 		Client:        client,
@@ -54,12 +75,12 @@ func (c *Config) CLICtx(client *rpchttp.HTTP) cctx.CLIContext {
 		Input:         os.Stdin,
 		Output:        os.Stdout,
 		OutputFormat:  "json",
-		From:          defaultKey,
+		From:          c.keyName(),
 		BroadcastMode: "sync",
-		FromName:      defaultKey,
+		FromName:      c.keyName(),
 		Codec:         c.Amino,
 		TrustNode:     true,
-		UseLedger:     false,
+		UseLedger:     c.useLedger,
 		Simulate:      false,
 		GenerateOnly:  false,
 		Indent:        true,
@@ -67,7 +88,18 @@ func (c *Config) CLICtx(client *rpchttp.HTTP) cctx.CLIContext {
 	}
 }
 
-// GetAccAddress returns the deployer account address
+// keyName returns the configured --from key, falling back to defaultKey
+// for configs written before named keys existed.
+func (c *Config) keyName() string {
+	if c.KeyName == "" {
+		return defaultKey
+	}
+	return c.KeyName
+}
+
+// GetAccAddress returns the deployer account address, or nil if the
+// configured key doesn't exist yet - e.g. before the first `deploy keys
+// add` on a fresh keyring.
 func (c *Config) GetAccAddress() sdk.AccAddress {
 	if c.address != nil {
 		return c.address
@@ -78,7 +110,10 @@ func (c *Config) GetAccAddress() sdk.AccAddress {
 	sdkConf.SetBech32PrefixForAccount(akashPrefix, akashPrefix+"pub")
 
 	if c.keybase != nil {
-		k, _ := c.keybase.Get(defaultKey)
+		k, err := c.keybase.Get(c.keyName())
+		if err != nil {
+			return nil
+		}
 		return k.GetAddress()
 	}
 	return nil
@@ -130,19 +165,12 @@ func validateConfig(c *Config) (err error) {
 	// Ensure that codecs exist
 	c.Amino = app.MakeCodec()
 
-	// If we are unable to create a new RPC client (rpc-addr doesn't parse) return err
-	if _, err = rpchttp.New(c.RPCAddr, "/websocket"); err != nil {
+	// If any configured RPC node can't be dialed, return err
+	if _, err = c.newPool(); err != nil {
 		return
 	}
 
-	// Warn if priv key specified and not exist at given path
-	keypath := path.Join(homePath, c.Keyfile)
-	if _, err = os.Stat(keypath); os.IsNotExist(err) {
-		fmt.Printf("Private key specified in the config file doesn't exist: %s\n", keypath)
-		return nil
-	}
-
-	// Warn if keypass isn't set or doesn't unlock the given keyfile?
+	// Open (or create) the on-disk keyring for the configured backend
 	if err = c.CreateKeybase(); err != nil {
 		return err
 	}
@@ -153,116 +181,77 @@ func validateConfig(c *Config) (err error) {
 	return
 }
 
-// NewTMClient returns a new tendermint RPC client from the config
-// NOTE: there shouldn't be errors here because we already check them
-// in validateConfig
-func (c *Config) NewTMClient() *rpchttp.HTTP {
-	out, _ := rpchttp.New(c.RPCAddr, "/websocket")
-	return out
+// NewTMClient returns a tendermint RPC client from the Config's node pool,
+// round-robining across whichever configured node is currently healthy.
+// Dialing every configured node and starting the health-checker happens
+// once, on first use.
+// NOTE: there shouldn't be errors here because we already check them in
+// validateConfig.
+func (c *Config) NewTMClient() tmclient.Client {
+	pool, _ := c.newPool()
+	return pool.Next()
 }
 
-// CreateKeybase returns the
-func (c *Config) CreateKeybase() (err error) {
-	kb := keys.NewInMemory()
-	kf, err := os.Open(path.Join(homePath, c.Keyfile))
-	if err != nil {
-		return
-	}
-	byt, err := ioutil.ReadAll(kf)
-	if err != nil {
-		return
+// newPool lazily dials c.rpcAddrs() into a tmclient.Pool, reusing it on
+// subsequent calls so the health-checker and any live subscriptions aren't
+// torn down and recreated on every RPC call.
+func (c *Config) newPool() (*tmclient.Pool, error) {
+	if c.pool == nil {
+		pool, err := tmclient.New(c.rpcAddrs())
+		if err != nil {
+			return nil, err
+		}
+		c.pool = pool
 	}
-	err = kb.ImportPrivKey(defaultKey, string(byt), c.Keypass)
-	c.keybase = kb
-	return
+	return c.pool, nil
 }
 
-// CreateKey creates a new private key
-func (c *Config) CreateKey() (err error) {
-	kp := path.Join(homePath, c.Keyfile)
-
-	if _, err := os.Stat(kp); !os.IsNotExist(err) {
-		return fmt.Errorf("keyfile %s already exists", kp)
-	} else {
-		fmt.Printf("Creating %s ...\n", kp)
-	}
-
-	kb := keys.NewInMemory()
-
-	entropySeed, err := bip39.NewEntropy(256)
-	if err != nil {
-		return err
-	}
-	mnemonic, err := bip39.NewMnemonic(entropySeed)
-	if err != nil {
-		return err
-	}
-
-	if _, err = kb.CreateAccount(defaultKey, mnemonic, defaultPass, defaultPass, keys.CreateHDPath(0, 0).String(), keys.Secp256k1); err != nil {
-		return err
-	}
-
-	armor, err := kb.ExportPrivKey(defaultKey, defaultPass, defaultPass)
-	if err != nil {
-		return err
+// CreateKeybase opens the on-disk keyring for the configured backend,
+// prompting for a passphrase as needed (the os/file backends require one,
+// test/memory do not). Set KEYRING_PASSPHRASE to answer the prompt
+// non-interactively, e.g. in CI.
+func (c *Config) CreateKeybase() (err error) {
+	if c.KeyringBackend == "" {
+		c.KeyringBackend = keys.BackendOS
 	}
-
-	return ioutil.WriteFile(kp, []byte(armor), 0644)
+	c.keybase, err = keys.NewKeyring(akashPrefix, c.KeyringBackend, homePath, passphraseInput())
+	return
 }
 
-// SendMsgs sends given sdk messages
-func (c *Config) SendMsgs(datagrams []sdk.Msg) (res sdk.TxResponse, err error) {
+// SendMsgs sends given sdk messages through the Config's Broadcaster,
+// which serializes signing so concurrent callers don't race over the
+// account sequence number.
+func (c *Config) SendMsgs(datagrams []sdk.Msg) (sdk.TxResponse, error) {
 	// validate basic all the msgs
 	for _, msg := range datagrams {
 		if err := msg.ValidateBasic(); err != nil {
-			return res, err
+			return sdk.TxResponse{}, err
 		}
 	}
 
-	var out []byte
-	if out, err = c.BuildAndSignTx(datagrams); err != nil {
-		return res, err
-	}
-	return c.BroadcastTxCommit(out)
+	result := <-c.Broadcaster().Send(datagrams)
+	return result.Response, result.Err
 }
 
-// BuildAndSignTx takes messages and builds, signs and marshals a sdk.Tx to prepare it for broadcast
+// BuildAndSignTx takes messages and builds, signs and marshals a sdk.Tx to
+// prepare it for broadcast. It goes through the Broadcaster's own
+// serializer goroutine, the same as SendMsgs, so the sequence number it
+// reserves can't race a concurrent Send. Kept for callers that need the
+// raw signed bytes in hand rather than a broadcast result; SendMsgs should
+// be preferred otherwise.
 func (c *Config) BuildAndSignTx(msgs []sdk.Msg) ([]byte, error) {
-	// Fetch account and sequence numbers for the account
-	var txBldr auth.TxBuilder
-	ctx := c.CLICtx(c.NewTMClient())
-	acc, err := auth.NewAccountRetriever(ctx).GetAccount(c.GetAccAddress())
-	if err != nil {
-		return nil, err
+	s, ok := c.Broadcaster().(*Serial)
+	if !ok {
+		return nil, fmt.Errorf("BuildAndSignTx requires a Serial broadcaster")
 	}
-
-	// Create the transaction builder with some sane defaults
-	// TODO: add some debug output?
-	txBldr = auth.NewTxBuilder(
-		auth.DefaultTxEncoder(c.Amino),
-		acc.GetAccountNumber(),
-		acc.GetSequence(),
-		200000,
-		c.gasAdj,
-		true,
-		c.ChainID,
-		"",
-		sdk.NewCoins(),
-		c.gasPrices,
-	).WithKeybase(c.keybase)
-
-	// Estimate the gas
-	if txBldr, err = authclient.EnrichWithGas(txBldr, ctx, msgs); err != nil {
-		return nil, err
-	}
-
-	// Return nil or the signature error
-	return txBldr.BuildAndSign(defaultKey, c.Keypass, msgs)
+	return s.Build(msgs)
 }
 
-// BroadcastTxCommit takes the marshaled transaction bytes and broadcasts them
+// BroadcastTxCommit takes marshaled transaction bytes and broadcasts them,
+// blocking for the commit result. Kept for callers that already have
+// signed bytes in hand (e.g. from BuildAndSignTx); prefer SendMsgs, which
+// also manages sequence numbers.
 func (c *Config) BroadcastTxCommit(txBytes []byte) (sdk.TxResponse, error) {
-	// TODO: add some debug output?
 	return c.CLICtx(c.NewTMClient()).BroadcastTxCommit(txBytes)
 }
 
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/go-bip39"
+	"github.com/spf13/cobra"
+)
+
+// envKeyringPassphrase, when set, answers keyring passphrase prompts
+// non-interactively so the os/file backends can be scripted (CI, systemd
+// units, etc).
+const envKeyringPassphrase = "KEYRING_PASSPHRASE"
+
+const (
+	flagKeyringBackend = "keyring-backend"
+	flagLedger         = "ledger"
+	flagUseLedger      = "use-ledger"
+)
+
+// keysCmd groups local key management, mirroring the gaiacli/akash `keys`
+// subcommand so users aren't forced to hand-place an armored file.
+func keysCmd() *cobra.Command {
+	var keyringBackend string
+	var useLedger bool
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "manage local keys",
+		// Applies --keyring-backend/--use-ledger before any subcommand
+		// runs, since by the time RunE executes, config's keybase has
+		// already been opened against whatever backend the config file
+		// set.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if useLedger {
+				config.useLedger = true
+			}
+			if keyringBackend != "" && keyringBackend != config.KeyringBackend {
+				config.KeyringBackend = keyringBackend
+				return config.CreateKeybase()
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&keyringBackend, flagKeyringBackend, "", "keyring backend to use (os|file|test|memory), overriding the configured one")
+	cmd.PersistentFlags().BoolVar(&useLedger, flagUseLedger, false, "sign with a key already present on a connected Ledger device")
+
+	cmd.AddCommand(
+		keysAddCmd(),
+		keysListCmd(),
+		keysShowCmd(),
+		keysDeleteCmd(),
+		keysExportCmd(),
+		keysImportCmd(),
+	)
+
+	return cmd
+}
+
+func keysAddCmd() *cobra.Command {
+	var ledger bool
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "create a new key from a freshly generated BIP39 mnemonic, or from a Ledger via --ledger; recovering from an existing mnemonic is not supported",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if ledger {
+				info, err := config.keybase.CreateLedger(name, keys.Secp256k1, akashPrefix, 0, 0)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("added ledger key %s: %s\n", name, info.GetAddress())
+				return nil
+			}
+
+			pass := signPassphrase()
+
+			entropy, err := bip39.NewEntropy(256)
+			if err != nil {
+				return err
+			}
+			mnemonic, err := bip39.NewMnemonic(entropy)
+			if err != nil {
+				return err
+			}
+
+			info, err := config.keybase.CreateAccount(name, mnemonic, "", pass, keys.CreateHDPath(0, 0).String(), keys.Secp256k1)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("added key %s: %s\n", name, info.GetAddress())
+			fmt.Printf("**Important** write this mnemonic phrase in a safe place.\nIt is the only way to recover your account if you ever forget your password.\n\n%s\n", mnemonic)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&ledger, flagLedger, false, "derive the key from a connected Ledger device instead of generating a mnemonic")
+	return cmd
+}
+
+func keysListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list all local keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infos, err := config.keybase.List()
+			if err != nil {
+				return err
+			}
+			for _, info := range infos {
+				fmt.Printf("%s\t%s\t%s\n", info.GetName(), info.GetAddress(), info.GetType())
+			}
+			return nil
+		},
+	}
+}
+
+func keysShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name>",
+		Short: "show the address and pubkey for a local key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := config.keybase.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\t%s\t%s\n", info.GetName(), info.GetAddress(), info.GetPubKey())
+			return nil
+		},
+	}
+}
+
+func keysDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "delete a local key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.keybase.Delete(args[0], signPassphrase(), false)
+		},
+	}
+}
+
+func keysExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export <name>",
+		Short: "export a key's armored, encrypted private key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pass := signPassphrase()
+			armor, err := config.keybase.ExportPrivKey(args[0], pass, pass)
+			if err != nil {
+				return err
+			}
+			fmt.Println(armor)
+			return nil
+		},
+	}
+}
+
+func keysImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <name> <keyfile>",
+		Short: "import an armored, encrypted private key",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			armor, err := ioutil.ReadFile(args[1])
+			if err != nil {
+				return err
+			}
+			return config.keybase.ImportPrivKey(args[0], string(armor), signPassphrase())
+		},
+	}
+}
+
+// signPassphrase returns the passphrase used to unlock the configured key,
+// preferring KEYRING_PASSPHRASE so non-interactive contexts never block on
+// a terminal prompt.
+func signPassphrase() string {
+	if pass := os.Getenv(envKeyringPassphrase); pass != "" {
+		return pass
+	}
+	return readPassphrase("Enter keyring passphrase: ")
+}
+
+func readPassphrase(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// passphraseInput returns the io.Reader the keyring backend reads
+// passphrase prompts from. When KEYRING_PASSPHRASE is set we answer every
+// prompt (new + confirm) from it so key creation scripts never block.
+func passphraseInput() io.Reader {
+	if pass := os.Getenv(envKeyringPassphrase); pass != "" {
+		return strings.NewReader(pass + "\n" + pass + "\n")
+	}
+	return os.Stdin
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSignPassphraseUsesEnvOverride(t *testing.T) {
+	os.Setenv(envKeyringPassphrase, "s3cret")
+	defer os.Unsetenv(envKeyringPassphrase)
+
+	if got := signPassphrase(); got != "s3cret" {
+		t.Errorf("signPassphrase() = %q, want %q", got, "s3cret")
+	}
+}
+
+func TestPassphraseInputUsesEnvOverride(t *testing.T) {
+	os.Setenv(envKeyringPassphrase, "s3cret")
+	defer os.Unsetenv(envKeyringPassphrase)
+
+	r := passphraseInput()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading passphraseInput() = %v", err)
+	}
+
+	want := "s3cret\ns3cret\n"
+	if string(got) != want {
+		t.Errorf("passphraseInput() = %q, want %q", got, want)
+	}
+}
+
+func TestPassphraseInputFallsBackToStdin(t *testing.T) {
+	os.Unsetenv(envKeyringPassphrase)
+
+	if r := passphraseInput(); r != os.Stdin {
+		t.Errorf("passphraseInput() = %v, want os.Stdin when %s is unset", r, envKeyringPassphrase)
+	}
+}
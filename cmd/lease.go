@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagProvider = "provider"
+	flagDSeq     = "dseq"
+	flagGSeq     = "gseq"
+	flagOSeq     = "oseq"
+)
+
+// AddLeaseIDFlags adds the --provider, --dseq, --gseq and --oseq flags used
+// to identify a lease to cmd.
+func AddLeaseIDFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagProvider, "", "provider address for the lease")
+	cmd.Flags().Uint64(flagDSeq, 0, "deployment sequence number")
+	cmd.Flags().Uint32(flagGSeq, 1, "group sequence number")
+	cmd.Flags().Uint32(flagOSeq, 1, "order sequence number")
+}
+
+// MarkLeaseIDFlagsRequired marks the flags added by AddLeaseIDFlags that
+// have no sane default as required.
+func MarkLeaseIDFlagsRequired(cmd *cobra.Command) {
+	_ = cmd.MarkFlagRequired(flagProvider)
+	_ = cmd.MarkFlagRequired(flagDSeq)
+}
+
+// LeaseIDFromFlags builds a mtypes.LeaseID from --provider, --dseq, --gseq
+// and --oseq, with owner (callers pass the deploying account) as the
+// lease owner.
+func LeaseIDFromFlags(flags *pflag.FlagSet, owner string) (mtypes.LeaseID, error) {
+	id := mtypes.LeaseID{Owner: owner}
+
+	provider, err := flags.GetString(flagProvider)
+	if err != nil {
+		return id, err
+	}
+	if _, err := sdk.AccAddressFromBech32(provider); err != nil {
+		return id, err
+	}
+	id.Provider = provider
+
+	if id.DSeq, err = flags.GetUint64(flagDSeq); err != nil {
+		return id, err
+	}
+	if id.GSeq, err = flags.GetUint32(flagGSeq); err != nil {
+		return id, err
+	}
+	if id.OSeq, err = flags.GetUint32(flagOSeq); err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+)
+
+func TestLeaseIDFromFlagsDefaultsOwner(t *testing.T) {
+	cmd := &cobra.Command{}
+	AddLeaseIDFlags(cmd)
+
+	provider := sdk.AccAddress(make([]byte, 20)).String()
+	if err := cmd.Flags().Set(flagProvider, provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Flags().Set(flagDSeq, "5"); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := LeaseIDFromFlags(cmd.Flags(), "akash1owner")
+	if err != nil {
+		t.Fatalf("LeaseIDFromFlags() error = %v", err)
+	}
+	if id.Owner != "akash1owner" || id.Provider != provider || id.DSeq != 5 || id.GSeq != 1 || id.OSeq != 1 {
+		t.Errorf("LeaseIDFromFlags() = %+v, want owner=akash1owner provider=%s dseq=5 gseq=1 oseq=1", id, provider)
+	}
+}
+
+func TestLeaseIDFromFlagsRejectsInvalidProvider(t *testing.T) {
+	cmd := &cobra.Command{}
+	AddLeaseIDFlags(cmd)
+	if err := cmd.Flags().Set(flagProvider, "not-a-bech32-address"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LeaseIDFromFlags(cmd.Flags(), "akash1owner"); err == nil {
+		t.Error("LeaseIDFromFlags() error = nil, want an error for an invalid provider address")
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/jackzampolin/deploy/provider"
+	"github.com/ovrclk/akash/sdl"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/spf13/cobra"
+)
+
+// providerCmd groups every command that talks directly to a provider's
+// gateway rather than to the chain.
+func providerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provider",
+		Short: "interact with a provider's gateway for an existing lease",
+	}
+
+	cmd.AddCommand(
+		sendManifestCmd(),
+		getManifestCmd(),
+		leaseStatusCmd(),
+		leaseEventsCmd(),
+		leaseLogsCmd(),
+		serviceStatusCmd(),
+		leaseShellCmd(),
+	)
+
+	return cmd
+}
+
+// leaseClientFromFlags is the common setup every provider subcommand needs:
+// parse the lease id off the flags and dial the owning provider's gateway.
+func leaseClientFromFlags(cmd *cobra.Command) (*provider.Client, mtypes.LeaseID, error) {
+	id, err := LeaseIDFromFlags(cmd.Flags(), config.GetAccAddress().String())
+	if err != nil {
+		return nil, id, err
+	}
+
+	providerAddr, err := sdk.AccAddressFromBech32(id.Provider)
+	if err != nil {
+		return nil, id, err
+	}
+
+	client, err := provider.NewClient(config.CLICtx(config.NewTMClient()), config.keybase, config.keyName(), signPassphrase(), config.GetAccAddress(), providerAddr)
+	if err != nil {
+		return nil, id, err
+	}
+	return client, id, nil
+}
+
+func sendManifestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send-manifest <sdl>",
+		Short: "send the manifest for a deployment to its provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			spec, err := sdl.Read(f)
+			if err != nil {
+				return err
+			}
+			mani, err := spec.Manifest()
+			if err != nil {
+				return err
+			}
+
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			return client.SendManifest(id, mani)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	return cmd
+}
+
+func getManifestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get-manifest",
+		Short: "fetch the manifest currently deployed to a provider",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			mani, err := client.GetManifest(id)
+			if err != nil {
+				return err
+			}
+			return config.Amino.MarshalJSONIndent(os.Stdout, mani)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	return cmd
+}
+
+func leaseStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lease-status",
+		Short: "show the status of every service in a lease",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			status, err := client.LeaseStatus(id)
+			if err != nil {
+				return err
+			}
+			return config.Amino.MarshalJSONIndent(os.Stdout, status)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	return cmd
+}
+
+func serviceStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service-status <service>",
+		Short: "show the status of a single service in a lease",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			status, err := client.ServiceStatus(id, args[0])
+			if err != nil {
+				return err
+			}
+			return config.Amino.MarshalJSONIndent(os.Stdout, status)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	return cmd
+}
+
+func leaseEventsCmd() *cobra.Command {
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "lease-events",
+		Short: "stream kubernetes events for a lease",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			return client.LeaseEvents(id, follow, os.Stdout)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	cmd.Flags().BoolVar(&follow, "follow", false, "keep streaming events as they occur")
+	return cmd
+}
+
+func leaseLogsCmd() *cobra.Command {
+	var follow bool
+	var service string
+	var tail int64
+	cmd := &cobra.Command{
+		Use:   "lease-logs",
+		Short: "stream container logs for a lease",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			return client.LeaseLogs(id, service, follow, tail, os.Stdout)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	cmd.Flags().BoolVar(&follow, "follow", false, "keep streaming logs as they are written")
+	cmd.Flags().StringVar(&service, "service", "", "restrict logs to a single service")
+	cmd.Flags().Int64Var(&tail, "tail", 0, "number of lines to show from the end of the logs (0 = provider default)")
+	return cmd
+}
+
+func leaseShellCmd() *cobra.Command {
+	var service string
+	var tty bool
+	cmd := &cobra.Command{
+		Use:   "lease-shell <service> -- <cmd> [args...]",
+		Short: "exec into a running service in a lease",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, id, err := leaseClientFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if service == "" {
+				service = args[0]
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				args = []string{"/bin/sh"}
+			}
+			return client.LeaseShell(id, service, args, tty, os.Stdin, os.Stdout, os.Stderr)
+		},
+	}
+	AddLeaseIDFlags(cmd)
+	MarkLeaseIDFlagsRequired(cmd)
+	cmd.Flags().StringVar(&service, "service", "", "service to exec into (defaults to the first positional arg)")
+	cmd.Flags().BoolVarP(&tty, "tty", "t", true, "allocate a pty for the remote command")
+	return cmd
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// eventsToMap flattens ABCI events into the "<type>.<attr>" -> values
+// shape ctypes.ResultEvent uses, so replayed tx_search results can be
+// reacted to the same way as live subscription events.
+func eventsToMap(events []abci.Event) map[string][]string {
+	out := map[string][]string{}
+	for _, ev := range events {
+		for _, attr := range ev.Attributes {
+			key := ev.Type + "." + string(attr.Key)
+			out[key] = append(out[key], string(attr.Value))
+		}
+	}
+	return out
+}
+
+// tmClient is the subset of the Tendermint RPC client the reactor needs,
+// declared as an interface so replay doesn't depend on the concrete client
+// type handed out by Config.NewTMClient's node pool.
+type tmClient interface {
+	Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan ctypes.ResultEvent, error)
+	Unsubscribe(ctx context.Context, subscriber, query string) error
+	TxSearch(query string, prove bool, page, perPage *int, orderBy string) (*ctypes.ResultTxSearch, error)
+}
+
+const (
+	reactorSubscriber = "deploy-reactor"
+
+	eventOrderCreated = "akash.v1.EventOrderCreated"
+	eventOrderClosed  = "akash.v1.EventOrderClosed"
+	eventBidCreated   = "akash.v1.EventBidCreated"
+	eventLeaseCreated = "akash.v1.EventLeaseCreated"
+	eventLeaseClosed  = "akash.v1.EventLeaseClosed"
+)
+
+// reactorEventTypes are the typed market events the reactor watches for,
+// each emitted by a transaction from an actor (a bidding provider, the
+// chain matching a lease) other than the deployment's own owner - so they
+// can't be found by filtering on MsgCreateDeployment, only on the typed
+// event's own id.owner attribute.
+var reactorEventTypes = []string{
+	eventOrderCreated,
+	eventOrderClosed,
+	eventBidCreated,
+	eventLeaseCreated,
+	eventLeaseClosed,
+}
+
+// Reactor subscribes to chain events scoped to a single deployment and
+// keeps a DeploymentData's order/lease tracking in sync as orders are
+// opened/matched and leases are created/closed.
+type Reactor struct {
+	cfg  *Config
+	data *DeploymentData
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewReactor returns a Reactor that drives data from the events of
+// data.DeploymentID (matched on its owner and dseq).
+func NewReactor(cfg *Config, data *DeploymentData) *Reactor {
+	return &Reactor{
+		cfg:        cfg,
+		data:       data,
+		minBackoff: time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+}
+
+// Run subscribes and reacts to events until ctx is cancelled. Disconnects
+// are retried with exponential backoff; on reconnect, any events missed
+// while disconnected are replayed via tx_search from the last height seen
+// before reacting to the live subscription again.
+func (r *Reactor) Run(ctx context.Context) error {
+	lastHeight, err := r.cfg.BlockHeight()
+	if err != nil {
+		return err
+	}
+
+	backoff := r.minBackoff
+	for ctx.Err() == nil {
+		height, err := r.subscribeAndReact(ctx, lastHeight)
+		lastHeight = height
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Printf("reactor: %v, reconnecting in %s\n", err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil
+			}
+			if backoff *= 2; backoff > r.maxBackoff {
+				backoff = r.maxBackoff
+			}
+			continue
+		}
+		backoff = r.minBackoff
+	}
+	return nil
+}
+
+// marketEventQuery matches a typed akash market event for this reactor's
+// deployment owner, keyed on the event's own id.owner attribute rather
+// than the enclosing tx's message - bids and lease matches are submitted
+// by the provider or the chain itself, never by the deployment owner, so
+// they'd never match a MsgCreateDeployment-scoped filter.
+func marketEventQuery(eventType, owner string) string {
+	return fmt.Sprintf("tm.event='Tx' AND %s.id.owner='%s'", eventType, owner)
+}
+
+// subscribeAndReact replays any events since lastHeight, then subscribes
+// live - one subscription per market event type - and reacts to events as
+// they arrive. It returns the last height reacted to so the caller can
+// resume from there after a reconnect.
+func (r *Reactor) subscribeAndReact(ctx context.Context, lastHeight uint64) (uint64, error) {
+	client := r.cfg.NewTMClient()
+
+	if err := r.replay(client, lastHeight); err != nil {
+		return lastHeight, err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	merged := make(chan ctypes.ResultEvent)
+	var wg sync.WaitGroup
+
+	for _, t := range reactorEventTypes {
+		query := marketEventQuery(t, r.data.DeploymentID.Owner)
+
+		events, err := client.Subscribe(subCtx, reactorSubscriber, query)
+		if err != nil {
+			return lastHeight, err
+		}
+		defer client.Unsubscribe(context.Background(), reactorSubscriber, query) // nolint: errcheck
+
+		wg.Add(1)
+		go func(events <-chan ctypes.ResultEvent) {
+			defer wg.Done()
+			for ev := range events {
+				select {
+				case merged <- ev:
+				case <-subCtx.Done():
+					return
+				}
+			}
+		}(events)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	for {
+		select {
+		case res, ok := <-merged:
+			if !ok {
+				return lastHeight, fmt.Errorf("reactor: all event subscriptions closed")
+			}
+			r.react(res)
+			if h, err := parseUint(res.Events["tx.height"]); err == nil {
+				lastHeight = h
+			}
+		case <-ctx.Done():
+			return lastHeight, nil
+		}
+	}
+}
+
+// replay re-delivers any matching txs between lastHeight and the chain's
+// current height, one query per market event type, covering whatever the
+// reactor missed while disconnected.
+func (r *Reactor) replay(client tmClient, lastHeight uint64) error {
+	height, err := r.cfg.BlockHeight()
+	if err != nil || height <= lastHeight {
+		return err
+	}
+
+	for _, t := range reactorEventTypes {
+		query := fmt.Sprintf("tx.height>%d AND %s.id.owner='%s'", lastHeight, t, r.data.DeploymentID.Owner)
+		if err := r.replayQuery(client, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reactor) replayQuery(client tmClient, query string) error {
+	page, seen := 1, 0
+	for {
+		res, err := client.TxSearch(query, false, &page, nil, "asc")
+		if err != nil {
+			return err
+		}
+		for _, tx := range res.Txs {
+			r.react(ctypes.ResultEvent{Query: query, Events: eventsToMap(tx.TxResult.Events)})
+		}
+		seen += len(res.Txs)
+		if len(res.Txs) > 0 && seen < res.TotalCount {
+			page++
+			continue
+		}
+		return nil
+	}
+}
+
+// react parses a single event's attributes and updates DeploymentData.
+// Events for any other deployment of the same owner are ignored.
+func (r *Reactor) react(res ctypes.ResultEvent) {
+	dseq := r.data.DeploymentID.DSeq
+	switch {
+	case len(res.Events[eventOrderCreated+".id.dseq"]) > 0:
+		if id, err := parseOrderID(res.Events); err == nil && id.DSeq == dseq {
+			r.data.AddOrder(id)
+		}
+	case len(res.Events[eventOrderClosed+".id.dseq"]) > 0:
+		if id, err := parseOrderID(res.Events); err == nil && id.DSeq == dseq {
+			r.data.RemoveOrder(id)
+		}
+	case len(res.Events[eventBidCreated+".id.dseq"]) > 0:
+		// DeploymentData has nowhere to track bids - only orders and
+		// leases - so there's nothing to update; log it so `deploy
+		// watch` shows activity while waiting for a lease to match.
+		if id, err := parseOrderIDAttrs(res.Events, eventBidCreated); err == nil && id.DSeq == dseq {
+			fmt.Printf("bid received for order %s\n", id)
+		}
+	case len(res.Events[eventLeaseCreated+".id.dseq"]) > 0:
+		if id, err := parseLeaseID(res.Events); err == nil && id.DSeq == dseq {
+			r.data.AddLease(id)
+		}
+	case len(res.Events[eventLeaseClosed+".id.dseq"]) > 0:
+		if id, err := parseLeaseID(res.Events); err == nil && id.DSeq == dseq {
+			r.data.RemoveLease(id)
+		}
+	}
+}
+
+func parseUint(vals []string) (uint64, error) {
+	if len(vals) == 0 {
+		return 0, fmt.Errorf("no value")
+	}
+	return strconv.ParseUint(vals[0], 10, 64)
+}
+
+// parseOrderID builds a mtypes.OrderID from the ABCI attributes of an
+// order event, keyed the way the akash market module emits them
+// (<event>.id.<field>).
+func parseOrderID(events map[string][]string) (mtypes.OrderID, error) {
+	return parseOrderIDAttrs(events, eventOrderCreated, eventOrderClosed)
+}
+
+func parseOrderIDAttrs(events map[string][]string, eventTypes ...string) (mtypes.OrderID, error) {
+	var id mtypes.OrderID
+	for _, t := range eventTypes {
+		owner, err1 := attr(events, t+".id.owner")
+		dseq, err2 := attrUint(events, t+".id.dseq")
+		gseq, err3 := attrUint(events, t+".id.gseq")
+		oseq, err4 := attrUint(events, t+".id.oseq")
+		if err1 == nil && err2 == nil && err3 == nil && err4 == nil {
+			id.Owner = owner
+			id.DSeq = dseq
+			id.GSeq = uint32(gseq)
+			id.OSeq = uint32(oseq)
+			return id, nil
+		}
+	}
+	return id, fmt.Errorf("event attributes missing order id")
+}
+
+// parseLeaseID builds a mtypes.LeaseID from the ABCI attributes of a
+// lease event.
+func parseLeaseID(events map[string][]string) (mtypes.LeaseID, error) {
+	var id mtypes.LeaseID
+	for _, t := range []string{eventLeaseCreated, eventLeaseClosed} {
+		owner, err1 := attr(events, t+".id.owner")
+		dseq, err2 := attrUint(events, t+".id.dseq")
+		gseq, err3 := attrUint(events, t+".id.gseq")
+		oseq, err4 := attrUint(events, t+".id.oseq")
+		provider, err5 := attr(events, t+".id.provider")
+		if err1 == nil && err2 == nil && err3 == nil && err4 == nil && err5 == nil {
+			id.Owner = owner
+			id.DSeq = dseq
+			id.GSeq = uint32(gseq)
+			id.OSeq = uint32(oseq)
+			id.Provider = provider
+			return id, nil
+		}
+	}
+	return id, fmt.Errorf("event attributes missing lease id")
+}
+
+func attr(events map[string][]string, key string) (string, error) {
+	vals, ok := events[key]
+	if !ok || len(vals) == 0 {
+		return "", fmt.Errorf("missing attribute %s", key)
+	}
+	return vals[0], nil
+}
+
+func attrUint(events map[string][]string, key string) (uint64, error) {
+	v, err := attr(events, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestMarketEventQuery(t *testing.T) {
+	got := marketEventQuery(eventLeaseCreated, "akash1owner")
+	want := "tm.event='Tx' AND akash.v1.EventLeaseCreated.id.owner='akash1owner'"
+	if got != want {
+		t.Errorf("marketEventQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestEventsToMap(t *testing.T) {
+	events := []abci.Event{
+		{
+			Type: eventOrderCreated,
+			Attributes: []abci.EventAttribute{
+				{Key: []byte("id.owner"), Value: []byte("akash1owner")},
+				{Key: []byte("id.dseq"), Value: []byte("1")},
+			},
+		},
+	}
+
+	out := eventsToMap(events)
+
+	if got := out[eventOrderCreated+".id.owner"]; len(got) != 1 || got[0] != "akash1owner" {
+		t.Errorf("eventsToMap()[%q] = %v, want [akash1owner]", eventOrderCreated+".id.owner", got)
+	}
+	if got := out[eventOrderCreated+".id.dseq"]; len(got) != 1 || got[0] != "1" {
+		t.Errorf("eventsToMap()[%q] = %v, want [1]", eventOrderCreated+".id.dseq", got)
+	}
+}
+
+func TestParseOrderID(t *testing.T) {
+	events := map[string][]string{
+		eventOrderCreated + ".id.owner": {"akash1owner"},
+		eventOrderCreated + ".id.dseq":  {"1"},
+		eventOrderCreated + ".id.gseq":  {"2"},
+		eventOrderCreated + ".id.oseq":  {"3"},
+	}
+
+	id, err := parseOrderID(events)
+	if err != nil {
+		t.Fatalf("parseOrderID() error = %v", err)
+	}
+	if id.Owner != "akash1owner" || id.DSeq != 1 || id.GSeq != 2 || id.OSeq != 3 {
+		t.Errorf("parseOrderID() = %+v, want owner=akash1owner dseq=1 gseq=2 oseq=3", id)
+	}
+
+	if _, err := parseOrderID(map[string][]string{}); err == nil {
+		t.Error("parseOrderID() with no attributes: want error, got nil")
+	}
+}
+
+func TestParseLeaseID(t *testing.T) {
+	events := map[string][]string{
+		eventLeaseCreated + ".id.owner":    {"akash1owner"},
+		eventLeaseCreated + ".id.dseq":     {"1"},
+		eventLeaseCreated + ".id.gseq":     {"2"},
+		eventLeaseCreated + ".id.oseq":     {"3"},
+		eventLeaseCreated + ".id.provider": {"akash1provider"},
+	}
+
+	id, err := parseLeaseID(events)
+	if err != nil {
+		t.Fatalf("parseLeaseID() error = %v", err)
+	}
+	if id.Owner != "akash1owner" || id.Provider != "akash1provider" || id.DSeq != 1 || id.GSeq != 2 || id.OSeq != 3 {
+		t.Errorf("parseLeaseID() = %+v, want owner=akash1owner provider=akash1provider dseq=1 gseq=2 oseq=3", id)
+	}
+}
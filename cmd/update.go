@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+
+	cctx "github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/jackzampolin/deploy/provider"
+	dcli "github.com/ovrclk/akash/x/deployment/client/cli"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/spf13/cobra"
+)
+
+// updateCmd implements `deploy update <sdl>`: the "bump the image tag"
+// workflow for a deployment whose SDL hasn't changed shape.
+func updateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update <sdl>",
+		Short: "update a live deployment's manifest without closing and recreating it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := dcli.DeploymentIDFromFlags(cmd.Flags(), config.GetAccAddress().String())
+			if err != nil {
+				return err
+			}
+
+			old, err := NewDeploymentDataFromDeployment(id)
+			if err != nil {
+				return err
+			}
+
+			new, err := NewDeploymentData(args[0], cmd.Flags(), config.GetAccAddress())
+			if err != nil {
+				return err
+			}
+			new.DeploymentID = id
+
+			_, err = config.UpdateDeployment(old, new)
+			return err
+		},
+	}
+	return cmd
+}
+
+// UpdateDeployment moves a live deployment from old to new. It rejects any
+// change that would require closing and recreating the deployment (a
+// different number of groups, or a group's placement/resource
+// requirements changing), broadcasts a MsgUpdateDeployment carrying new's
+// manifest version, and resends the new manifest to every provider
+// already holding a lease against the deployment.
+func (c *Config) UpdateDeployment(old, new *DeploymentData) (sdk.TxResponse, error) {
+	if err := diffGroups(old.Groups, new.Groups); err != nil {
+		return sdk.TxResponse{}, err
+	}
+
+	msg := dtypes.MsgUpdateDeployment{
+		ID:      new.DeploymentID,
+		Version: new.Version,
+	}
+	if err := msg.ValidateBasic(); err != nil {
+		return sdk.TxResponse{}, err
+	}
+
+	res, err := c.SendMsgs([]sdk.Msg{msg})
+	if err != nil {
+		return res, err
+	}
+
+	for _, lease := range old.Leases() {
+		providerAddr, err := sdk.AccAddressFromBech32(lease.Provider)
+		if err != nil {
+			return res, err
+		}
+		client, err := provider.NewClient(c.CLICtx(c.NewTMClient()), c.keybase, c.keyName(), signPassphrase(), c.GetAccAddress(), providerAddr)
+		if err != nil {
+			return res, err
+		}
+		if err := client.SendManifest(lease, new.Manifest); err != nil {
+			return res, fmt.Errorf("resending manifest to %s: %w", lease.Provider, err)
+		}
+	}
+
+	return res, nil
+}
+
+// diffGroups rejects any group-level change between old and new that
+// would require closing and recreating the deployment rather than just
+// resending its manifest: a different number of groups, a renamed (or
+// removed) group, or a group whose placement or resource requirements
+// changed. Groups are matched by name rather than position, since an SDL
+// edit that only reorders groups shouldn't be treated as renaming every
+// one of them.
+func diffGroups(old, new []*dtypes.GroupSpec) error {
+	if len(old) != len(new) {
+		return fmt.Errorf("sdl changes the number of groups (%d -> %d); this requires closing and recreating the deployment", len(old), len(new))
+	}
+
+	byName := make(map[string]*dtypes.GroupSpec, len(old))
+	for _, og := range old {
+		byName[og.Name] = og
+	}
+
+	for _, ng := range new {
+		og, ok := byName[ng.Name]
+		if !ok {
+			return fmt.Errorf("group %q not found in the existing deployment; this requires closing and recreating the deployment", ng.Name)
+		}
+		if !reflect.DeepEqual(og.Requirements, ng.Requirements) {
+			return fmt.Errorf("group %q changes placement requirements; this requires closing and recreating the deployment", ng.Name)
+		}
+		if !reflect.DeepEqual(og.Resources, ng.Resources) {
+			return fmt.Errorf("group %q changes resource requirements; this requires closing and recreating the deployment", ng.Name)
+		}
+	}
+
+	return nil
+}
+
+// NewDeploymentDataFromDeployment reconstructs the groups and leases of an
+// existing on-chain deployment, for diffing against an updated SDL.
+func NewDeploymentDataFromDeployment(id dtypes.DeploymentID) (*DeploymentData, error) {
+	cliCtx := config.CLICtx(config.NewTMClient())
+
+	res, _, err := cliCtx.QueryWithData(
+		fmt.Sprintf("custom/%s/deployment/%s/%d", dtypes.QuerierRoute, id.Owner, id.DSeq),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying deployment %s: %w", id, err)
+	}
+
+	var deployment dtypes.QueryDeploymentResponse
+	if err := config.Amino.UnmarshalJSON(res, &deployment); err != nil {
+		return nil, err
+	}
+
+	groups := make([]*dtypes.GroupSpec, 0, len(deployment.Groups))
+	for _, g := range deployment.Groups {
+		spec := g.GroupSpec
+		groups = append(groups, &spec)
+	}
+
+	leases, err := queryLeases(cliCtx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentData{
+		DeploymentID: id,
+		Groups:       groups,
+		LeaseID:      leases,
+	}, nil
+}
+
+// queryLeases fetches the currently active leases against a deployment, so
+// an update knows which providers need the new manifest resent to them.
+func queryLeases(cliCtx cctx.CLIContext, id dtypes.DeploymentID) ([]mtypes.LeaseID, error) {
+	params := mtypes.QueryLeasesFilters{Owner: id.Owner, DSeq: id.DSeq}
+	bz, err := config.Amino.MarshalJSON(params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/leases", mtypes.QuerierRoute), bz)
+	if err != nil {
+		return nil, fmt.Errorf("querying leases for deployment %s: %w", id, err)
+	}
+
+	var out mtypes.QueryLeasesResponse
+	if err := config.Amino.UnmarshalJSON(res, &out); err != nil {
+		return nil, err
+	}
+
+	ids := make([]mtypes.LeaseID, 0, len(out.Leases))
+	for _, lease := range out.Leases {
+		ids = append(ids, lease.LeaseID)
+	}
+	return ids, nil
+}
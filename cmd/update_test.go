@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+)
+
+func TestDiffGroupsAllowsIdenticalGroups(t *testing.T) {
+	old := []*dtypes.GroupSpec{{Name: "web"}, {Name: "db"}}
+	new := []*dtypes.GroupSpec{{Name: "web"}, {Name: "db"}}
+
+	if err := diffGroups(old, new); err != nil {
+		t.Errorf("diffGroups() = %v, want nil", err)
+	}
+}
+
+func TestDiffGroupsAllowsReorderedGroups(t *testing.T) {
+	old := []*dtypes.GroupSpec{{Name: "web"}, {Name: "db"}}
+	new := []*dtypes.GroupSpec{{Name: "db"}, {Name: "web"}}
+
+	if err := diffGroups(old, new); err != nil {
+		t.Errorf("diffGroups() = %v, want nil for a reorder-only change", err)
+	}
+}
+
+func TestDiffGroupsRejectsGroupCountChange(t *testing.T) {
+	old := []*dtypes.GroupSpec{{Name: "web"}}
+	new := []*dtypes.GroupSpec{{Name: "web"}, {Name: "db"}}
+
+	if err := diffGroups(old, new); err == nil {
+		t.Error("diffGroups() = nil, want an error for a changed group count")
+	}
+}
+
+func TestDiffGroupsRejectsRenamedGroup(t *testing.T) {
+	old := []*dtypes.GroupSpec{{Name: "web"}}
+	new := []*dtypes.GroupSpec{{Name: "frontend"}}
+
+	if err := diffGroups(old, new); err == nil {
+		t.Error("diffGroups() = nil, want an error for a renamed group")
+	}
+}
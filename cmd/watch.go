@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/jackzampolin/deploy/provider"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd blocks on `deploy watch <sdl>` until every group in the
+// deployment has a matching lease, then sends the manifest to each
+// provider automatically.
+func watchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch <sdl>",
+		Short: "wait for a deployment's leases to be matched, then send the manifest to each provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := NewDeploymentData(args[0], cmd.Flags(), config.GetAccAddress())
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reactor := NewReactor(config, data)
+			errCh := make(chan error, 1)
+			go func() { errCh <- reactor.Run(ctx) }()
+
+			for !data.ExpectedLeases() {
+				select {
+				case err := <-errCh:
+					return err
+				case <-time.After(time.Second):
+				}
+			}
+			cancel()
+
+			return sendManifests(data)
+		},
+	}
+	return cmd
+}
+
+// sendManifests sends data's manifest to every provider behind a matched
+// lease.
+func sendManifests(data *DeploymentData) error {
+	for _, lease := range data.Leases() {
+		providerAddr, err := sdk.AccAddressFromBech32(lease.Provider)
+		if err != nil {
+			return err
+		}
+		client, err := provider.NewClient(config.CLICtx(config.NewTMClient()), config.keybase, config.keyName(), signPassphrase(), config.GetAccAddress(), providerAddr)
+		if err != nil {
+			return err
+		}
+		if err := client.SendManifest(lease, data.Manifest); err != nil {
+			return fmt.Errorf("sending manifest to %s: %w", lease.Provider, err)
+		}
+	}
+	return nil
+}
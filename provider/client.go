@@ -0,0 +1,314 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	cctx "github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/websocket"
+	"github.com/ovrclk/akash/manifest"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	ptypes "github.com/ovrclk/akash/x/provider/types"
+)
+
+// tokenLifetime is how long a signed request token remains valid. Provider
+// gateways reject anything older, so we mint a fresh one per Client call.
+const tokenLifetime = 30 * time.Second
+
+// signingMethodSecp256k1Alg is the JWT "alg" header value for tokens signed
+// through secp256k1SigningMethod.
+const signingMethodSecp256k1Alg = "SECP256K1"
+
+// secp256k1SigningMethod implements jwt.SigningMethod over the same
+// secp256k1 signature a cosmos-sdk Keybase produces. None of jwt-go's
+// built-in methods match: the deploying account's key is secp256k1, not an
+// RSA or P-256 key, and the provider gateway verifies the token against the
+// owner's on-chain account pubkey rather than anything jwt-go itself
+// understands. Registering a real method (instead of labelling the token
+// ES256, which this isn't) keeps the header honest about what produced it.
+type secp256k1SigningMethod struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(signingMethodSecp256k1Alg, func() jwt.SigningMethod {
+		return secp256k1SigningMethod{}
+	})
+}
+
+func (secp256k1SigningMethod) Alg() string { return signingMethodSecp256k1Alg }
+
+// Verify is unused on the client: we only ever sign tokens here, and the
+// provider gateway verifies them against the owner's on-chain pubkey.
+func (secp256k1SigningMethod) Verify(signingString, signature string, key interface{}) error {
+	return fmt.Errorf("secp256k1: verification is not supported by this client")
+}
+
+// Sign expects key to be a func([]byte) ([]byte, error) that produces a
+// cosmos-sdk secp256k1 signature over signingString, e.g. a Keybase.Sign
+// closure.
+func (secp256k1SigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	signer, ok := key.(func([]byte) ([]byte, error))
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	sig, err := signer([]byte(signingString))
+	if err != nil {
+		return "", err
+	}
+	return jwt.EncodeSegment(sig), nil
+}
+
+// Client talks to a single provider's JWT-authenticated REST gateway.
+type Client struct {
+	host       string
+	from       sdk.AccAddress
+	keyname    string
+	keybase    keys.Keybase
+	passphrase string
+
+	http *http.Client
+}
+
+// NewClient builds a Client for the provider at providerAddr. It resolves the
+// provider's gateway host from the on-chain provider registry. passphrase
+// unlocks keyname the same way it does for tx signing - callers should
+// resolve it the same way (e.g. cmd.signPassphrase).
+func NewClient(cliCtx cctx.CLIContext, keybase keys.Keybase, keyname, passphrase string, from sdk.AccAddress, providerAddr sdk.AccAddress) (*Client, error) {
+	host, err := queryHost(cliCtx, providerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("resolving provider %s: %w", providerAddr, err)
+	}
+
+	return &Client{
+		host:       host,
+		from:       from,
+		keyname:    keyname,
+		keybase:    keybase,
+		passphrase: passphrase,
+		http: &http.Client{
+			Transport: &http.Transport{
+				// providers self-sign; the on-chain lease already proves identity
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+func queryHost(cliCtx cctx.CLIContext, addr sdk.AccAddress) (string, error) {
+	res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/providers/%s", ptypes.QuerierRoute, addr), nil)
+	if err != nil {
+		return "", err
+	}
+	var p ptypes.Provider
+	if err := cliCtx.Codec.UnmarshalJSON(res, &p); err != nil {
+		return "", err
+	}
+	if p.HostURI == "" {
+		return "", fmt.Errorf("provider %s has no host uri", addr)
+	}
+	return p.HostURI, nil
+}
+
+// SendManifest submits the manifest for the deployment behind lease.
+func (c *Client) SendManifest(id mtypes.LeaseID, mani manifest.Manifest) error {
+	body, err := json.Marshal(mani)
+	if err != nil {
+		return err
+	}
+	res, err := c.doRequest(id, http.MethodPut, leasePath(id, "manifest"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkStatus(res)
+}
+
+// GetManifest fetches the manifest currently deployed for lease.
+func (c *Client) GetManifest(id mtypes.LeaseID) (manifest.Manifest, error) {
+	var mani manifest.Manifest
+	res, err := c.doRequest(id, http.MethodGet, leasePath(id, "manifest"), nil)
+	if err != nil {
+		return mani, err
+	}
+	defer res.Body.Close()
+	if err := checkStatus(res); err != nil {
+		return mani, err
+	}
+	return mani, json.NewDecoder(res.Body).Decode(&mani)
+}
+
+// LeaseStatus fetches the current status of every service in the lease.
+func (c *Client) LeaseStatus(id mtypes.LeaseID) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	res, err := c.doRequest(id, http.MethodGet, leasePath(id, "status"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkStatus(res); err != nil {
+		return nil, err
+	}
+	return out, json.NewDecoder(res.Body).Decode(&out)
+}
+
+// ServiceStatus fetches the status of a single service within the lease.
+func (c *Client) ServiceStatus(id mtypes.LeaseID, service string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	res, err := c.doRequest(id, http.MethodGet, leasePath(id, "service", service, "status"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkStatus(res); err != nil {
+		return nil, err
+	}
+	return out, json.NewDecoder(res.Body).Decode(&out)
+}
+
+// LeaseEvents streams Kubernetes events for the lease to out until follow
+// stops or the connection drops. When follow is false the stream is closed
+// after the initial backlog is flushed.
+func (c *Client) LeaseEvents(id mtypes.LeaseID, follow bool, out io.Writer) error {
+	return c.stream(id, leasePath(id, "kubeevents"), url.Values{
+		"follow": []string{fmt.Sprintf("%t", follow)},
+	}, out)
+}
+
+// LeaseLogs streams container logs for the lease to out. tailLines <= 0
+// requests the provider default.
+func (c *Client) LeaseLogs(id mtypes.LeaseID, service string, follow bool, tailLines int64, out io.Writer) error {
+	q := url.Values{"follow": []string{fmt.Sprintf("%t", follow)}}
+	if service != "" {
+		q.Set("service", service)
+	}
+	if tailLines > 0 {
+		q.Set("tail", fmt.Sprintf("%d", tailLines))
+	}
+	return c.stream(id, leasePath(id, "logs"), q, out)
+}
+
+// LeaseShell opens an interactive exec session inside a running service
+// container, multiplexing stdin/stdout/stderr over a single websocket
+// connection the way `kubectl exec` does.
+func (c *Client) LeaseShell(id mtypes.LeaseID, service string, cmdArgs []string, tty bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	q := url.Values{
+		"service": []string{service},
+		"tty":     []string{fmt.Sprintf("%t", tty)},
+	}
+	for _, arg := range cmdArgs {
+		q.Add("cmd", arg)
+	}
+
+	conn, err := c.dialWS(id, leasePath(id, "shell"), q)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return muxShell(conn, tty, stdin, stdout, stderr)
+}
+
+func (c *Client) doRequest(id mtypes.LeaseID, method, path string, body io.Reader) (*http.Response, error) {
+	token, err := c.signToken(id)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, c.host+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.http.Do(req)
+}
+
+func (c *Client) stream(id mtypes.LeaseID, path string, query url.Values, out io.Writer) error {
+	conn, err := c.dialWS(id, path, query)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+		if _, err := out.Write(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) dialWS(id mtypes.LeaseID, path string, query url.Values) (*websocket.Conn, error) {
+	token, err := c.signToken(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(c.host + path)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = "wss"
+	u.RawQuery = query.Encode()
+
+	dialer := websocket.Dialer{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	header := http.Header{"Authorization": []string{"Bearer " + token}}
+
+	conn, res, err := dialer.Dial(u.String(), header)
+	if err != nil {
+		if res != nil {
+			defer res.Body.Close()
+			if b, rerr := ioutil.ReadAll(res.Body); rerr == nil && len(b) > 0 {
+				return nil, fmt.Errorf("dial %s: %w: %s", u, err, b)
+			}
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// signToken produces a short-lived JWT proving control of the deploying
+// account, scoped to the lease being acted on. The provider gateway
+// verifies the signature against the owner address embedded in the lease.
+func (c *Client) signToken(id mtypes.LeaseID) (string, error) {
+	claims := jwt.MapClaims{
+		"leaseID": id,
+		"exp":     time.Now().Add(tokenLifetime).Unix(),
+	}
+	token := jwt.NewWithClaims(secp256k1SigningMethod{}, claims)
+
+	return token.SignedString(func(msg []byte) ([]byte, error) {
+		sig, _, err := c.keybase.Sign(c.keyname, c.passphrase, msg)
+		return sig, err
+	})
+}
+
+func leasePath(id mtypes.LeaseID, parts ...string) string {
+	p := fmt.Sprintf("/lease/%d/%d/%d", id.DSeq, id.GSeq, id.OSeq)
+	for _, part := range parts {
+		p += "/" + part
+	}
+	return p
+}
+
+func checkStatus(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	return fmt.Errorf("provider returned %d: %s", res.StatusCode, body)
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+)
+
+func TestLeasePath(t *testing.T) {
+	id := mtypes.LeaseID{DSeq: 1, GSeq: 2, OSeq: 3}
+
+	got := leasePath(id)
+	want := "/lease/1/2/3"
+	if got != want {
+		t.Errorf("leasePath() = %q, want %q", got, want)
+	}
+
+	got = leasePath(id, "service", "web", "status")
+	want = "/lease/1/2/3/service/web/status"
+	if got != want {
+		t.Errorf("leasePath() = %q, want %q", got, want)
+	}
+}
+
+func TestSecp256k1SigningMethodAlg(t *testing.T) {
+	if got := (secp256k1SigningMethod{}).Alg(); got != signingMethodSecp256k1Alg {
+		t.Errorf("Alg() = %q, want %q", got, signingMethodSecp256k1Alg)
+	}
+}
+
+func TestSecp256k1SigningMethodSign(t *testing.T) {
+	m := secp256k1SigningMethod{}
+
+	signer := func(msg []byte) ([]byte, error) {
+		return append([]byte("sig:"), msg...), nil
+	}
+
+	got, err := m.Sign("signing-string", signer)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	want := jwt.EncodeSegment([]byte("sig:signing-string"))
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+
+	if _, err := m.Sign("signing-string", "not-a-signer-func"); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Sign() with wrong key type error = %v, want %v", err, jwt.ErrInvalidKeyType)
+	}
+}
+
+func TestSecp256k1SigningMethodSignPropagatesError(t *testing.T) {
+	m := secp256k1SigningMethod{}
+	wantErr := errors.New("locked")
+
+	_, err := m.Sign("signing-string", func(msg []byte) ([]byte, error) {
+		return nil, wantErr
+	})
+	if err != wantErr {
+		t.Errorf("Sign() error = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// shellMsg is the framing used over the lease-shell websocket: each message
+// carries a single channel's bytes, mirroring the stdin/stdout/stderr/resize
+// channels kubectl's remotecommand protocol multiplexes over one stream.
+type shellMsg struct {
+	Channel string `json:"channel"`
+	Data    []byte `json:"data,omitempty"`
+	Rows    uint16 `json:"rows,omitempty"`
+	Cols    uint16 `json:"cols,omitempty"`
+}
+
+const (
+	channelStdin  = "stdin"
+	channelStdout = "stdout"
+	channelStderr = "stderr"
+	channelResize = "resize"
+)
+
+// wsWriter serializes writes to a *websocket.Conn from multiple goroutines
+// - gorilla/websocket forbids concurrent writers on one connection, and
+// muxShell has both a stdin pump and a resize watcher writing to it.
+type wsWriter struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (w *wsWriter) writeJSON(msg shellMsg) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// muxShell pumps stdin to the connection and demultiplexes stdout/stderr
+// from it until either side closes the connection. When tty is set and
+// stdin is an *os.File attached to a terminal, it also switches that
+// terminal into raw mode for the duration of the call and forwards its
+// size to the remote pty on SIGWINCH.
+func muxShell(conn *websocket.Conn, tty bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	w := &wsWriter{conn: conn}
+
+	if tty {
+		if f, ok := stdin.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+			state, err := terminal.MakeRaw(int(f.Fd()))
+			if err != nil {
+				return err
+			}
+			defer terminal.Restore(int(f.Fd()), state) // nolint: errcheck
+
+			stop := watchResize(w, f)
+			defer close(stop)
+		}
+	}
+
+	errCh := make(chan error, 2)
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if werr := w.writeJSON(shellMsg{Channel: channelStdin, Data: buf[:n]}); werr != nil {
+					errCh <- werr
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					errCh <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+
+		var msg shellMsg
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return err
+		}
+
+		switch msg.Channel {
+		case channelStdout:
+			if _, err := stdout.Write(msg.Data); err != nil {
+				return err
+			}
+		case channelStderr:
+			if _, err := stderr.Write(msg.Data); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case err := <-errCh:
+			return err
+		default:
+		}
+	}
+}
+
+// watchResize sends f's current terminal size as a resize channel message,
+// then again on every SIGWINCH, until stop is closed - keeping the remote
+// pty's size in sync with the local terminal's. Writes go through w so
+// they can't interleave with muxShell's stdin pump on the same connection.
+func watchResize(w *wsWriter, f *os.File) chan struct{} {
+	stop := make(chan struct{})
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGWINCH)
+
+	send := func() {
+		cols, rows, err := terminal.GetSize(int(f.Fd()))
+		if err != nil {
+			return
+		}
+		w.writeJSON(shellMsg{Channel: channelResize, Rows: uint16(rows), Cols: uint16(cols)}) // nolint: errcheck
+	}
+
+	go func() {
+		send()
+		for {
+			select {
+			case <-sigCh:
+				send()
+			case <-stop:
+				signal.Stop(sigCh)
+				return
+			}
+		}
+	}()
+
+	return stop
+}
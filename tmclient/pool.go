@@ -0,0 +1,154 @@
+// Package tmclient provides a pooled Tendermint RPC client that spreads
+// calls across multiple nodes and fails over when one falls behind or
+// stops responding, so a single flaky RPC endpoint doesn't take the tool
+// down with it.
+package tmclient
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// Client is a Tendermint RPC client.
+type Client = rpcclient.Client
+
+const (
+	defaultCheckEvery = 15 * time.Second
+	defaultStaleAfter = 10 // blocks behind the tallest peer before a node is considered stale
+)
+
+// node tracks one endpoint's client and the health-checker's last view of
+// it. healthy is read and written with sync/atomic only, so Pool.Next can
+// pick a node without taking a lock.
+type node struct {
+	addr    string
+	client  *rpchttp.HTTP
+	healthy int32 // atomic bool: 1 healthy, 0 not
+}
+
+// Pool round-robins calls across a list of Tendermint RPC endpoints. A
+// background health-checker polls /status on each node, tracks how far
+// behind it is, and marks it unhealthy on a network error or a height
+// more than staleAfter blocks behind the tallest peer seen; Next skips
+// unhealthy nodes when choosing where to send the next call.
+//
+// Pool deliberately doesn't hand out one long-lived client and fail it
+// over in place - every caller in package cmd already asks for a fresh
+// client per RPC call (or, for a subscription, per reconnect attempt), so
+// Next just needs to return a currently-healthy node each time it's
+// asked.
+type Pool struct {
+	nodes  []*node
+	cursor uint32
+
+	staleAfter int64
+	checkEvery time.Duration
+	stop       chan struct{}
+}
+
+// New dials every address in addrs and returns a Pool that round-robins
+// across whichever are healthy. addrs must be non-empty; a single address
+// is a valid (degenerate) pool, preserving backward compatibility with a
+// config that still sets a single rpc-addr.
+func New(addrs []string) (*Pool, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("tmclient: at least one rpc address is required")
+	}
+
+	p := &Pool{
+		staleAfter: defaultStaleAfter,
+		checkEvery: defaultCheckEvery,
+		stop:       make(chan struct{}),
+	}
+
+	for _, addr := range addrs {
+		c, err := rpchttp.New(addr, "/websocket")
+		if err != nil {
+			return nil, fmt.Errorf("tmclient: dialing %s: %w", addr, err)
+		}
+		p.nodes = append(p.nodes, &node{addr: addr, client: c, healthy: 1})
+	}
+
+	p.refreshHealth()
+	go p.healthLoop()
+
+	return p, nil
+}
+
+// Close stops the background health-checker.
+func (p *Pool) Close() {
+	close(p.stop)
+}
+
+func (p *Pool) healthLoop() {
+	ticker := time.NewTicker(p.checkEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.refreshHealth()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// refreshHealth polls every node's /status and marks it unhealthy if it
+// errors, is still catching up, or has fallen more than staleAfter blocks
+// behind the tallest peer seen this round.
+func (p *Pool) refreshHealth() {
+	var tallest int64
+	heights := make([]int64, len(p.nodes))
+
+	for i, n := range p.nodes {
+		status, err := n.client.Status()
+		if err != nil {
+			atomic.StoreInt32(&n.healthy, 0)
+			continue
+		}
+		h := status.SyncInfo.LatestBlockHeight
+		heights[i] = h
+		if h > tallest {
+			tallest = h
+		}
+		atomic.StoreInt32(&n.healthy, boolToInt32(!status.SyncInfo.CatchingUp))
+	}
+
+	for i, n := range p.nodes {
+		if tallest-heights[i] > p.staleAfter {
+			atomic.StoreInt32(&n.healthy, 0)
+		}
+	}
+}
+
+// Next returns the next healthy node in round-robin order. If every node
+// currently looks unhealthy it still round-robins rather than refusing to
+// try, since a stale health check shouldn't permanently wedge the pool.
+func (p *Pool) Next() Client {
+	return p.next().client
+}
+
+// next is Next's node-selection logic, split out so it can be tested
+// without needing a dialed *rpchttp.HTTP client on every node.
+func (p *Pool) next() *node {
+	n := len(p.nodes)
+	start := int(atomic.AddUint32(&p.cursor, 1))
+	for i := 0; i < n; i++ {
+		node := p.nodes[(start+i)%n]
+		if atomic.LoadInt32(&node.healthy) == 1 {
+			return node
+		}
+	}
+	return p.nodes[start%n]
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package tmclient
+
+import "testing"
+
+func TestPoolNextRoundRobinsHealthyNodes(t *testing.T) {
+	a, b := &node{addr: "a", healthy: 1}, &node{addr: "b", healthy: 1}
+	p := &Pool{nodes: []*node{a, b}}
+
+	seen := map[*node]bool{}
+	for i := 0; i < 4; i++ {
+		seen[p.next()] = true
+	}
+
+	if !seen[a] || !seen[b] {
+		t.Fatalf("next() saw %v, want both nodes to be picked over several calls", seen)
+	}
+}
+
+func TestPoolNextSkipsUnhealthyNodes(t *testing.T) {
+	unhealthy := &node{addr: "a", healthy: 0}
+	healthy := &node{addr: "b", healthy: 1}
+	p := &Pool{nodes: []*node{unhealthy, healthy}}
+
+	for i := 0; i < 4; i++ {
+		if got := p.next(); got != healthy {
+			t.Fatalf("next() = %v, want the only healthy node %v", got, healthy)
+		}
+	}
+}
+
+func TestPoolNextFallsBackWhenAllUnhealthy(t *testing.T) {
+	a := &node{addr: "a", healthy: 0}
+	b := &node{addr: "b", healthy: 0}
+	p := &Pool{nodes: []*node{a, b}}
+
+	// Even with no healthy node, Next must still return something rather
+	// than block or panic.
+	if got := p.next(); got != a && got != b {
+		t.Fatalf("next() = %v, want one of the configured nodes", got)
+	}
+}
+
+func TestBoolToInt32(t *testing.T) {
+	if boolToInt32(true) != 1 {
+		t.Error("boolToInt32(true) != 1")
+	}
+	if boolToInt32(false) != 0 {
+		t.Error("boolToInt32(false) != 0")
+	}
+}